@@ -0,0 +1,96 @@
+package main
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// dynamicCache is a bounded, LRU-evicting cache of dynamically materialized
+// exact-match entries created by a prefix (or default-service) lookup miss.
+// It is kept separate from the static exact-match table so that unbounded,
+// high-cardinality traffic (unique paths per request) can't grow memory
+// without limit: once maxEntries is reached, inserting a new entry evicts
+// the least-recently-used one.
+type dynamicCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List               // front = most recently used
+	entries    map[string]*list.Element // path -> element in ll
+}
+
+type dynamicCacheEntry struct {
+	path        string
+	destination string
+}
+
+func newDynamicCache(maxEntries int) *dynamicCache {
+	return &dynamicCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// get looks up path and, on a hit, marks it most-recently-used.
+func (c *dynamicCache) get(path string) (destination string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[path]
+	if !ok {
+		return "", false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*dynamicCacheEntry).destination, true
+}
+
+// set inserts or updates path -> destination, marking it most-recently-used,
+// and reports whether inserting it evicted the least-recently-used entry.
+func (c *dynamicCache) set(path, destination string) (evicted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[path]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*dynamicCacheEntry).destination = destination
+		return false
+	}
+
+	el := c.ll.PushFront(&dynamicCacheEntry{path: path, destination: destination})
+	c.entries[path] = el
+
+	if c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.entries, oldest.Value.(*dynamicCacheEntry).path)
+		return true
+	}
+	return false
+}
+
+// flushShadowedBy removes every cached entry whose path has the given
+// prefix ("" flushes everything) and reports how many entries it removed.
+func (c *dynamicCache) flushShadowedBy(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for el := c.ll.Front(); el != nil; {
+		next := el.Next()
+		entry := el.Value.(*dynamicCacheEntry)
+		if prefix == "" || strings.HasPrefix(entry.path, prefix) {
+			c.ll.Remove(el)
+			delete(c.entries, entry.path)
+			removed++
+		}
+		el = next
+	}
+	return removed
+}
+
+func (c *dynamicCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
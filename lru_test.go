@@ -0,0 +1,139 @@
+package main
+
+import "testing"
+
+func TestRouterDynamicCacheBounded(t *testing.T) {
+	r := NewRouter()
+	r.MaxDynamicEntries = 3
+	// MaxDynamicEntries only takes effect for tables built after it's set.
+	r.tbl.Store(newTables(r.maxDynamicEntries()))
+
+	if err := r.AddRoute("/api/", "prefix", "service-1"); err != nil {
+		t.Fatalf("AddRoute returned error: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := r.RouteLookup(pathFor(i)); err != nil {
+			t.Fatalf("RouteLookup returned error: %v", err)
+		}
+	}
+
+	stats := r.Stats()
+	if stats.DynamicEntries != 3 {
+		t.Errorf("Stats().DynamicEntries = %d, want 3 (MaxDynamicEntries)", stats.DynamicEntries)
+	}
+	if stats.Evictions != 2 {
+		t.Errorf("Stats().Evictions = %d, want 2", stats.Evictions)
+	}
+	if stats.Misses != 5 {
+		t.Errorf("Stats().Misses = %d, want 5", stats.Misses)
+	}
+
+	// The most recently inserted paths should still be cached; the oldest
+	// two should have been evicted and therefore miss the dynamic cache
+	// again (still resolving correctly via the prefix table, just counted
+	// as another miss).
+	missesBefore := r.Stats().Misses
+	if _, err := r.RouteLookup(pathFor(4)); err != nil {
+		t.Fatalf("RouteLookup returned error: %v", err)
+	}
+	if r.Stats().Misses != missesBefore {
+		t.Errorf("RouteLookup(%s) caused a dynamic cache miss, want a hit", pathFor(4))
+	}
+
+	if _, err := r.RouteLookup(pathFor(0)); err != nil {
+		t.Fatalf("RouteLookup returned error: %v", err)
+	}
+	if r.Stats().Misses != missesBefore+1 {
+		t.Errorf("RouteLookup(%s) did not cause a dynamic cache miss as expected after eviction", pathFor(0))
+	}
+}
+
+func TestRouterFlushShadowedByPrefix(t *testing.T) {
+	r := NewRouter()
+
+	if err := r.AddRoute("/api/", "prefix", "service-1"); err != nil {
+		t.Fatalf("AddRoute returned error: %v", err)
+	}
+	if err := r.AddRoute("/other/", "prefix", "service-2"); err != nil {
+		t.Fatalf("AddRoute returned error: %v", err)
+	}
+
+	if _, err := r.RouteLookup("/api/1"); err != nil {
+		t.Fatalf("RouteLookup returned error: %v", err)
+	}
+	if _, err := r.RouteLookup("/other/1"); err != nil {
+		t.Fatalf("RouteLookup returned error: %v", err)
+	}
+
+	// Modifying the "/api/" prefix should only flush dynamic entries it
+	// shadows, leaving the "/other/" entry cached.
+	if err := r.AddRoute("/api/", "prefix", "service-1-v2"); err != nil {
+		t.Fatalf("AddRoute returned error: %v", err)
+	}
+
+	if stats := r.Stats(); stats.Flushes != 1 {
+		t.Errorf("Stats().Flushes = %d, want 1", stats.Flushes)
+	}
+
+	missesBefore := r.Stats().Misses
+	if got, err := r.RouteLookup("/api/1"); err != nil || got != "service-1-v2" {
+		t.Errorf("RouteLookup(/api/1) = (%q, %v), want service-1-v2", got, err)
+	}
+	if r.Stats().Misses != missesBefore+1 {
+		t.Errorf("RouteLookup(/api/1) should have missed the dynamic cache after the prefix changed")
+	}
+
+	if got, err := r.RouteLookup("/other/1"); err != nil || got != "service-2" {
+		t.Errorf("RouteLookup(/other/1) = (%q, %v), want service-2", got, err)
+	}
+	if r.Stats().Misses != missesBefore+1 {
+		t.Errorf("RouteLookup(/other/1) should still have hit the dynamic cache")
+	}
+}
+
+func pathFor(i int) string {
+	return "/api/" + string(rune('a'+i))
+}
+
+// TestRouterFlushesOnNewParamRoute covers a brand-new parameterized route,
+// not just a modification of an existing one: a path cached as
+// default-service before the pattern existed must not keep resolving to
+// default-service forever once a pattern is added that now matches it.
+func TestRouterFlushesOnNewParamRoute(t *testing.T) {
+	r := NewRouter()
+
+	result, err := r.RouteLookupParams("/user/bob")
+	if err != nil || result.Destination != "default-service" {
+		t.Fatalf("RouteLookupParams(/user/bob) = (%+v, %v), want default-service", result, err)
+	}
+
+	if err := r.AddRoute("/user/{name}", "exact", "by-name"); err != nil {
+		t.Fatalf("AddRoute returned error: %v", err)
+	}
+
+	result, err = r.RouteLookupParams("/user/bob")
+	if err != nil || result.Destination != "by-name" || result.Params["name"] != "bob" {
+		t.Errorf("RouteLookupParams(/user/bob) after AddRoute = (%+v, %v), want by-name with name=bob", result, err)
+	}
+}
+
+// TestRouterFlushesOnNewPrefixRoute covers a brand-new prefix route, not
+// just a modification of an existing one: a path cached as default-service
+// before the prefix existed must not keep resolving to default-service
+// forever once a prefix is added that now shadows it.
+func TestRouterFlushesOnNewPrefixRoute(t *testing.T) {
+	r := NewRouter()
+
+	if got, err := r.RouteLookup("/api/foo"); err != nil || got != "default-service" {
+		t.Fatalf("RouteLookup(/api/foo) = (%q, %v), want default-service", got, err)
+	}
+
+	if err := r.AddRoute("/api/", "prefix", "service-new"); err != nil {
+		t.Fatalf("AddRoute returned error: %v", err)
+	}
+
+	if got, err := r.RouteLookup("/api/foo"); err != nil || got != "service-new" {
+		t.Errorf("RouteLookup(/api/foo) after AddRoute = (%q, %v), want service-new", got, err)
+	}
+}
@@ -1,20 +1,20 @@
 package main
 // operation can be "addRoute" or "routeLookup"
-// If operation is "addRoute", 
-//      arg1 is  the path, 
+// If operation is "addRoute",
+//      arg1 is  the path,
 //      arg2 is the type of match ("exact" or "prefix")
 //      arg3 is the destination
 //      expected1 is nil
 // If operation is "routeLookup"
 //      arg1 is the path,
-//      expected1 is the destination expected 
-//      expected2 should is any errors that are expected to be returned 
+//      expected1 is the destination expected
+//      expected2 should is any errors that are expected to be returned
 
 
 import "testing"
 
 type pathLookupsTest struct {
-     operation, arg1, arg2, arg3, expected1, expected2   string 
+     operation, arg1, arg2, arg3, expected1, expected2   string
 }
 
 var addLookupTests = []pathLookupsTest{
@@ -34,26 +34,65 @@ var addLookupTests = []pathLookupsTest{
 
 }
 
-// Could structure these tests a bit differently to test the RouteAdds  
+// Could structure these tests a bit differently to test the RouteAdds
 // separately from RouteLookups.. functionally this works for now
+//
+// Uses its own Router rather than the package-level DefaultRouter so the
+// test doesn't depend on running before/after the other tests in this
+// package mutate shared state.
 func TestRouteAddsLookups(t *testing.T){
 
+    r := NewRouter()
+
     for _, test := range addLookupTests{
         if test.operation == "addRoute" {
-             output := AddRoute(test.arg1, test.arg2, test.arg3)
+             output := r.AddRoute(test.arg1, test.arg2, test.arg3)
              if test.expected1 == "" && output != nil {
                  t.Errorf("Output %q not equal to expected %q", output, test.expected1)
-             } 
+             }
         }
         if test.operation == "routeLookup" {
-             output1, output2 := RouteLookup(test.arg1)
+             output1, output2 := r.RouteLookup(test.arg1)
              if output1 != test.expected1 {
                  t.Errorf("Output %q not equal to expected %q", output1, test.expected1)
-             } 
+             }
              if test.expected2 == "" && output2 != nil {
                  t.Errorf("Output %q not equal to expected %q", output2, test.expected2)
-             } 
+             }
         }
     }
 }
 
+// TestRouteLookupParams exercises the parameterized/catch-all route tree
+// added alongside RouteLookupParams, on its own Router for the same
+// isolation reason as TestRouteAddsLookups above.
+func TestRouteLookupParams(t *testing.T) {
+
+    r := NewRouter()
+
+    if err := r.AddRoute("/user/{name}", "exact", "by-name"); err != nil {
+        t.Fatalf("AddRoute(/user/{name}) returned error: %v", err)
+    }
+    if err := r.AddRoute("/user/admin", "exact", "admin-literal"); err != nil {
+        t.Fatalf("AddRoute(/user/admin) returned error: %v", err)
+    }
+    if err := r.AddRoute("/files/{path...}", "prefix", "file-server"); err != nil {
+        t.Fatalf("AddRoute(/files/{path...}) returned error: %v", err)
+    }
+
+    result, err := r.RouteLookupParams("/user/admin")
+    if err != nil || result.Destination != "admin-literal" {
+        t.Errorf("RouteLookupParams(/user/admin) = (%+v, %v), want admin-literal", result, err)
+    }
+
+    result, err = r.RouteLookupParams("/user/bob")
+    if err != nil || result.Destination != "by-name" || result.Params["name"] != "bob" {
+        t.Errorf("RouteLookupParams(/user/bob) = (%+v, %v), want by-name with name=bob", result, err)
+    }
+
+    result, err = r.RouteLookupParams("/files/a/b.txt")
+    if err != nil || result.Destination != "file-server" || result.Params["path"] != "a/b.txt" {
+        t.Errorf("RouteLookupParams(/files/a/b.txt) = (%+v, %v), want file-server with path=a/b.txt", result, err)
+    }
+}
+
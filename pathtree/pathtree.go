@@ -0,0 +1,217 @@
+// Package pathtree implements a per-segment route tree supporting named
+// path parameters ("/user/{name}") and catch-all segments
+// ("/files/{path...}"), in the style of fasthttp/router.
+//
+// Patterns are split on "/" and matched one segment at a time. At each
+// level, a literal segment is tried before a parameter segment, and a
+// catch-all (which consumes every remaining segment) is tried last and only
+// as a fallback -- so a pattern with more literal segments always wins over
+// one with fewer, and a catch-all only ever wins when nothing more specific
+// matched.
+package pathtree
+
+import "strings"
+
+// Result is what Lookup returns for a matched route.
+type Result struct {
+	Destination string
+	Params      map[string]string
+}
+
+// node is one path segment in the tree.
+type node struct {
+	literalChildren map[string]*node
+
+	paramChild *node
+	paramName  string
+
+	catchAllName  string
+	catchAllValue string
+	hasCatchAll   bool
+
+	value    string
+	hasValue bool
+}
+
+// Tree is a route tree keyed by "/"-separated path patterns.
+type Tree struct {
+	root *node
+	size int
+}
+
+// New returns an empty Tree.
+func New() *Tree {
+	return &Tree{root: &node{}}
+}
+
+// Len returns the number of patterns inserted.
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// HasPattern reports whether pattern contains a "{...}" segment, i.e.
+// whether it belongs in a Tree at all rather than in a plain exact/prefix
+// table.
+func HasPattern(pattern string) bool {
+	return strings.Contains(pattern, "{")
+}
+
+// Insert adds pattern -> destination to the tree, creating or modifying as
+// needed. It reports whether the pattern was newly created.
+func (t *Tree) Insert(pattern string, destination string) (isNew bool) {
+	segs := splitSegments(pattern)
+	n := t.root
+	for i, seg := range segs {
+		name, isCatchAll, isParam := parseSegment(seg)
+		if isCatchAll {
+			isNew = !n.hasCatchAll
+			n.hasCatchAll = true
+			n.catchAllName = name
+			n.catchAllValue = destination
+			if isNew {
+				t.size++
+			}
+			return isNew
+		}
+		if isParam {
+			if n.paramChild == nil {
+				n.paramChild = &node{}
+				n.paramName = name
+			}
+			n = n.paramChild
+			continue
+		}
+		if n.literalChildren == nil {
+			n.literalChildren = make(map[string]*node)
+		}
+		child, ok := n.literalChildren[seg]
+		if !ok {
+			child = &node{}
+			n.literalChildren[seg] = child
+		}
+		n = child
+		_ = i
+	}
+	isNew = !n.hasValue
+	n.hasValue = true
+	n.value = destination
+	if isNew {
+		t.size++
+	}
+	return isNew
+}
+
+// Delete removes pattern from the tree, if present, and reports whether it
+// was found. It does not prune now-empty intermediate nodes; those cost
+// nothing at lookup time since they carry no value and are never the
+// longest match.
+func (t *Tree) Delete(pattern string) bool {
+	segs := splitSegments(pattern)
+	n := t.root
+	for _, seg := range segs {
+		name, isCatchAll, isParam := parseSegment(seg)
+		if isCatchAll {
+			if !n.hasCatchAll || n.catchAllName != name {
+				return false
+			}
+			n.hasCatchAll = false
+			n.catchAllName = ""
+			n.catchAllValue = ""
+			t.size--
+			return true
+		}
+		if isParam {
+			if n.paramChild == nil || n.paramName != name {
+				return false
+			}
+			n = n.paramChild
+			continue
+		}
+		child, ok := n.literalChildren[seg]
+		if !ok {
+			return false
+		}
+		n = child
+	}
+	if !n.hasValue {
+		return false
+	}
+	n.hasValue = false
+	n.value = ""
+	t.size--
+	return true
+}
+
+// Lookup returns the best match for path along with any parameters it bound,
+// following the precedence described in the package comment: literal and
+// parameterized matches (in decreasing order of how many literal segments
+// they have) are preferred over a catch-all. matchedCatchAll reports whether
+// the winning match was a catch-all, which callers use to rank catch-all
+// matches below other route types such as a plain prefix match.
+func (t *Tree) Lookup(path string) (result Result, matchedCatchAll bool, ok bool) {
+	segs := splitSegments(path)
+	return t.root.match(segs, nil)
+}
+
+func (n *node) match(segs []string, params map[string]string) (Result, bool, bool) {
+	if len(segs) == 0 {
+		if n.hasValue {
+			return Result{Destination: n.value, Params: params}, false, true
+		}
+		return Result{}, false, false
+	}
+
+	seg, rest := segs[0], segs[1:]
+
+	if child, ok := n.literalChildren[seg]; ok {
+		if res, isCatchAll, ok := child.match(rest, params); ok {
+			return res, isCatchAll, true
+		}
+	}
+
+	if n.paramChild != nil {
+		if res, isCatchAll, ok := n.paramChild.match(rest, withParam(params, n.paramName, seg)); ok {
+			return res, isCatchAll, true
+		}
+	}
+
+	if n.hasCatchAll {
+		return Result{
+			Destination: n.catchAllValue,
+			Params:      withParam(params, n.catchAllName, strings.Join(segs, "/")),
+		}, true, true
+	}
+
+	return Result{}, false, false
+}
+
+func withParam(params map[string]string, name, value string) map[string]string {
+	out := make(map[string]string, len(params)+1)
+	for k, v := range params {
+		out[k] = v
+	}
+	out[name] = value
+	return out
+}
+
+// parseSegment classifies a single "/"-separated pattern segment.
+func parseSegment(seg string) (name string, isCatchAll bool, isParam bool) {
+	if len(seg) < 2 || seg[0] != '{' || seg[len(seg)-1] != '}' {
+		return "", false, false
+	}
+	inner := seg[1 : len(seg)-1]
+	if strings.HasSuffix(inner, "...") {
+		return strings.TrimSuffix(inner, "..."), true, false
+	}
+	return inner, false, true
+}
+
+// splitSegments splits a "/"-rooted path/pattern into its segments, keeping
+// a trailing empty segment so that e.g. "/api/2/" and "/api/2" remain
+// distinct, matching the rest of the router's trailing-slash handling.
+func splitSegments(path string) []string {
+	if strings.HasPrefix(path, "/") {
+		path = path[1:]
+	}
+	return strings.Split(path, "/")
+}
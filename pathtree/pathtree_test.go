@@ -0,0 +1,80 @@
+package pathtree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLiteralBeatsParamBeatsCatchAll(t *testing.T) {
+	tr := New()
+	tr.Insert("/user/{name}", "by-name")
+	tr.Insert("/user/admin", "admin-literal")
+	tr.Insert("/user/{path...}", "catch-all")
+
+	res, isCatchAll, ok := tr.Lookup("/user/admin")
+	if !ok || isCatchAll || res.Destination != "admin-literal" {
+		t.Fatalf("Lookup(/user/admin) = (%+v, %v, %v), want admin-literal", res, isCatchAll, ok)
+	}
+
+	res, isCatchAll, ok = tr.Lookup("/user/bob")
+	if !ok || isCatchAll || res.Destination != "by-name" || res.Params["name"] != "bob" {
+		t.Fatalf("Lookup(/user/bob) = (%+v, %v, %v), want by-name with name=bob", res, isCatchAll, ok)
+	}
+
+	res, isCatchAll, ok = tr.Lookup("/user/bob/settings")
+	if !ok || !isCatchAll || res.Destination != "catch-all" || res.Params["path"] != "bob/settings" {
+		t.Fatalf("Lookup(/user/bob/settings) = (%+v, %v, %v), want catch-all with path=bob/settings", res, isCatchAll, ok)
+	}
+}
+
+func TestNoMatch(t *testing.T) {
+	tr := New()
+	tr.Insert("/user/{name}", "by-name")
+	if _, _, ok := tr.Lookup("/other"); ok {
+		t.Fatalf("Lookup(/other) should miss")
+	}
+}
+
+func TestMultipleParams(t *testing.T) {
+	tr := New()
+	tr.Insert("/repos/{owner}/{name}", "repo")
+
+	res, _, ok := tr.Lookup("/repos/srampal/route-matching")
+	if !ok || res.Destination != "repo" {
+		t.Fatalf("Lookup failed: %+v %v", res, ok)
+	}
+	want := map[string]string{"owner": "srampal", "name": "route-matching"}
+	if !reflect.DeepEqual(res.Params, want) {
+		t.Errorf("Params = %v, want %v", res.Params, want)
+	}
+}
+
+func TestDeleteAndModify(t *testing.T) {
+	tr := New()
+	if isNew := tr.Insert("/user/{name}", "v1"); !isNew {
+		t.Fatalf("first insert should report isNew=true")
+	}
+	if isNew := tr.Insert("/user/{name}", "v2"); isNew {
+		t.Fatalf("re-insert should report isNew=false")
+	}
+	res, _, ok := tr.Lookup("/user/bob")
+	if !ok || res.Destination != "v2" {
+		t.Fatalf("expected updated destination v2, got %+v %v", res, ok)
+	}
+
+	if !tr.Delete("/user/{name}") {
+		t.Fatalf("Delete should report found")
+	}
+	if _, _, ok := tr.Lookup("/user/bob"); ok {
+		t.Fatalf("Lookup should miss after delete")
+	}
+}
+
+func TestHasPattern(t *testing.T) {
+	if HasPattern("/api/2/") {
+		t.Errorf("HasPattern(/api/2/) should be false")
+	}
+	if !HasPattern("/user/{name}") {
+		t.Errorf("HasPattern(/user/{name}) should be true")
+	}
+}
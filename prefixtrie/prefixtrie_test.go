@@ -0,0 +1,122 @@
+package prefixtrie
+
+import "testing"
+
+func TestInsertLookupBasic(t *testing.T) {
+	tr := New()
+	tr.Insert("/api/2/1", "service-3")
+	tr.Insert("/api/2/", "service-4")
+	tr.Insert("/api/1", "service-5")
+	tr.Insert("/api/2/1/1", "service-6")
+
+	cases := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"/api/1/2", "service-5", true},
+		{"/api/3", "", false},
+		{"/api/2/1/2", "service-3", true},
+		{"/api/2/", "service-4", true},
+	}
+	for _, c := range cases {
+		got, ok := tr.Lookup(c.path)
+		if got != c.want || ok != c.ok {
+			t.Errorf("Lookup(%q) = (%q, %v), want (%q, %v)", c.path, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestInsertModifiesExisting(t *testing.T) {
+	tr := New()
+	if isNew := tr.Insert("/api/2/", "service-4"); !isNew {
+		t.Fatalf("first insert of /api/2/ should report isNew=true")
+	}
+	if isNew := tr.Insert("/api/2/", "service-7"); isNew {
+		t.Fatalf("re-insert of /api/2/ should report isNew=false")
+	}
+	if got, ok := tr.Lookup("/api/2/"); !ok || got != "service-7" {
+		t.Fatalf("Lookup(/api/2/) = (%q, %v), want (service-7, true)", got, ok)
+	}
+	if tr.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", tr.Len())
+	}
+}
+
+// Regression: the resulting trie (and thus its lookups) must not depend on
+// the order in which a prefix and a longer prefix that extends it are
+// inserted, whether or not that requires splitting an existing edge.
+func TestParentPrefixInsertedInDifferentOrders(t *testing.T) {
+	t.Run("short-then-long", func(t *testing.T) {
+		tr := New()
+		tr.Insert("/api/1", "short")
+		tr.Insert("/api/12", "long")
+		assertLookup(t, tr, "/api/1", "short")
+		assertLookup(t, tr, "/api/12", "long")
+		assertLookup(t, tr, "/api/123", "long")
+	})
+
+	t.Run("long-then-short", func(t *testing.T) {
+		tr := New()
+		tr.Insert("/api/12", "long")
+		tr.Insert("/api/1", "short")
+		assertLookup(t, tr, "/api/1", "short")
+		assertLookup(t, tr, "/api/12", "long")
+		assertLookup(t, tr, "/api/123", "long")
+	})
+}
+
+// Regression: inserting a key that diverges partway through an existing edge
+// must split that edge rather than clobbering it.
+func TestComputePrefixSplit(t *testing.T) {
+	tr := New()
+	tr.Insert("/api/alpha", "a")
+	tr.Insert("/api/beta", "b")
+
+	assertLookup(t, tr, "/api/alpha", "a")
+	assertLookup(t, tr, "/api/alphabet", "a")
+	assertLookup(t, tr, "/api/beta", "b")
+	if _, ok := tr.Lookup("/api/"); ok {
+		t.Fatalf("Lookup(/api/) should miss: /api/ was never inserted and the split branch node carries no value")
+	}
+}
+
+func TestDeleteFallsBackToParent(t *testing.T) {
+	tr := New()
+	tr.Insert("/api", "outer")
+	tr.Insert("/api/1", "inner")
+
+	if !tr.Delete("/api/1") {
+		t.Fatalf("Delete(/api/1) should report found")
+	}
+	assertLookup(t, tr, "/api/1", "outer")
+	assertLookup(t, tr, "/api", "outer")
+
+	if tr.Delete("/api/1") {
+		t.Fatalf("deleting /api/1 twice should report not found the second time")
+	}
+}
+
+func TestDeleteCompressesSingleChildChain(t *testing.T) {
+	tr := New()
+	tr.Insert("/api/alpha", "a")
+	tr.Insert("/api/beta", "b")
+
+	if !tr.Delete("/api/beta") {
+		t.Fatalf("Delete(/api/beta) should report found")
+	}
+	// only one child remains under the /api/ branch node, so it should be
+	// merged back into a single edge; lookups must keep working regardless.
+	assertLookup(t, tr, "/api/alpha", "a")
+	if _, ok := tr.Lookup("/api/beta"); ok {
+		t.Fatalf("Lookup(/api/beta) should miss after delete")
+	}
+}
+
+func assertLookup(t *testing.T, tr *Table, path, want string) {
+	t.Helper()
+	got, ok := tr.Lookup(path)
+	if !ok || got != want {
+		t.Errorf("Lookup(%q) = (%q, %v), want (%q, true)", path, got, ok, want)
+	}
+}
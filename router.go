@@ -0,0 +1,345 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/srampal/route-matching/pathtree"
+	"github.com/srampal/route-matching/prefixtrie"
+)
+
+// DefaultMaxDynamicEntries is the dynamic cache size a Router gets when its
+// MaxDynamicEntries field is left at zero.
+const DefaultMaxDynamicEntries = 10000
+
+// tables is the full set of routing state for one Router: the prefix trie,
+// the parameterized route tree, the static exact-match table, the bounded
+// dynamic cache of materialized prefix/default-service hits, and the
+// RouteSpec list from routespec.go. It is always replaced wholesale rather
+// than mutated field-by-field from outside this file, so that Restore can
+// swap in a freshly built instance behind Router.tbl without readers ever
+// observing a half-updated table.
+//
+// exact is a sync.Map rather than a plain map so that Lookup checking for a
+// static cache hit never has to take Router.mu: that's by far the most
+// frequent operation, and it commutes with itself regardless of how many
+// goroutines are doing it concurrently. dyn is a separately synchronized
+// bounded LRU (see lru.go) rather than living in exact, so that
+// high-cardinality traffic can't grow the cache without bound.
+type tables struct {
+	prefix *prefixtrie.Table // prefix trie of prefix match routes
+	params *pathtree.Tree    // per-segment tree of named/wildcard routes
+	exact  sync.Map          // path string -> route; static entries only
+	dyn    *dynamicCache     // bounded LRU of dynamically materialized entries
+
+	// RouteSpecs added via AddRouteSpec, indexed the same way the plain
+	// exact/prefix tables above are so MatchRequest can build a candidate set
+	// with a lookup instead of an O(n) scan over every registered spec.
+	// specExact and specPrefixEntries can each hold more than one entry per
+	// path -- unlike a plain route, several specs can share a Path and differ
+	// only by Method/Host/Headers -- so specPrefix (a prefixtrie.Table) maps a
+	// spec Path to itself just to get the longest-prefix-match machinery;
+	// the entries themselves live in specPrefixEntries keyed by that Path.
+	// specRegexps holds PathRegexp specs, which aren't indexable this way and
+	// fall back to a specificity-ordered linear scan as before.
+	specExact         map[string][]*routeSpecEntry
+	specPrefix        *prefixtrie.Table
+	specPrefixEntries map[string][]*routeSpecEntry
+	specRegexps       []*routeSpecEntry
+
+	records []routeRecord // user-added routes, in insertion order, for Snapshot/Restore
+}
+
+func newTables(maxDynamicEntries int) *tables {
+	return &tables{
+		prefix:            prefixtrie.New(),
+		params:            pathtree.New(),
+		dyn:               newDynamicCache(maxDynamicEntries),
+		specExact:         make(map[string][]*routeSpecEntry),
+		specPrefix:        prefixtrie.New(),
+		specPrefixEntries: make(map[string][]*routeSpecEntry),
+	}
+}
+
+// Router holds one independent set of routing tables. The tables are kept
+// behind an atomic.Pointer so that Restore can swap in a freshly built set
+// without readers taking a lock; see snapshot.go. mu serializes mutations
+// of the prefix trie, param tree, spec list and record list against each
+// other and against the Lookup code paths that walk them -- unlike exact,
+// those structures mutate in place and aren't safe for concurrent use on
+// their own.
+type Router struct {
+	tbl atomic.Pointer[tables]
+	mu  sync.RWMutex
+
+	// MaxDynamicEntries bounds the size of the dynamic cache of materialized
+	// prefix/default-service lookups. Zero means DefaultMaxDynamicEntries.
+	// Set it before the Router sees traffic; changing it afterwards only
+	// takes effect for tables built later (e.g. by Restore).
+	MaxDynamicEntries int
+
+	hits      atomic.Uint64 // dynamic cache hits
+	misses    atomic.Uint64 // dynamic cache misses (fell through to param/prefix tables)
+	evictions atomic.Uint64 // dynamic cache entries evicted to stay within MaxDynamicEntries
+	flushes   atomic.Uint64 // dynamic cache entries flushed as stale by AddRoute/DeleteRoute
+}
+
+// Stats summarizes a Router's dynamic cache behavior, for tuning
+// MaxDynamicEntries to a deployment's traffic pattern.
+type Stats struct {
+	Hits           uint64
+	Misses         uint64
+	Evictions      uint64
+	Flushes        uint64
+	DynamicEntries int
+}
+
+// Stats reports r's dynamic cache counters and current size.
+func (r *Router) Stats() Stats {
+	t := r.tbl.Load()
+	return Stats{
+		Hits:           r.hits.Load(),
+		Misses:         r.misses.Load(),
+		Evictions:      r.evictions.Load(),
+		Flushes:        r.flushes.Load(),
+		DynamicEntries: t.dyn.len(),
+	}
+}
+
+// maxDynamicEntries resolves MaxDynamicEntries to the effective cache size.
+func (r *Router) maxDynamicEntries() int {
+	if r.MaxDynamicEntries > 0 {
+		return r.MaxDynamicEntries
+	}
+	return DefaultMaxDynamicEntries
+}
+
+// NewRouter returns an empty Router, ready for AddRoute/AddRouteSpec calls.
+func NewRouter() *Router {
+	r := &Router{}
+	r.tbl.Store(newTables(r.maxDynamicEntries()))
+	return r
+}
+
+// AddRoute is called to add a new route or modify an existing route with behavior as described in the package comments
+func (r *Router) AddRoute(path string, matchType string, destination string) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.tbl.Load()
+
+	// Perform input validation here (skipped for now)
+
+	// A path containing a "{name}" or "{path...}" segment is a parameterized
+	// route and is matched by segment via t.params rather than by the plain
+	// exact/prefix tables below, regardless of matchType.
+
+	if pathtree.HasPattern(path) {
+		t.params.Insert(path, destination)
+		// A param/catch-all pattern doesn't reduce to a literal prefix, so
+		// there's no cheap shadow test here; flush the whole cache. This is
+		// needed on every insert, not just a modification of an existing
+		// pattern: a brand-new pattern can shadow paths that were already
+		// cached as default-service (or a less-specific match).
+		r.flush(t, "")
+		t.recordRoute(routeRecord{Path: path, MatchType: matchType, Destination: destination})
+		return nil
+	}
+
+	// If this is an exact route, insert into t.exact (modify also automatically handled)
+	// Note: any prior entry is freed and garbage collected, (could also add explicit freeing
+	// as an optimization to not depend on the garbage collector)
+
+	if matchType == "exact" {
+		t.exact.Store(path, route{
+			path:        path,
+			matchType:   matchType,
+			destination: destination,
+		})
+		t.recordRoute(routeRecord{Path: path, MatchType: matchType, Destination: destination})
+		return nil
+	}
+
+	// So this is a prefix route. Flush dynamic entries it shadows whether
+	// this is a brand-new prefix or a modification of an existing one: a
+	// new prefix can just as easily shadow paths that were already cached
+	// (as default-service, or as a less-specific prefix's destination) as a
+	// changed one can leave them stale.
+
+	t.prefix.Insert(path, destination)
+	r.flush(t, path)
+	t.recordRoute(routeRecord{Path: path, MatchType: matchType, Destination: destination})
+
+	return nil
+}
+
+// DeleteRoute removes a previously added route. For exact routes this drops
+// the entry from t.exact; for prefix routes it removes the prefix from the
+// prefix trie and flushes dynamic cache entries shadowed by it, since paths
+// that were being served from the deleted prefix may now resolve
+// differently (to a shorter prefix, or to the default service).
+func (r *Router) DeleteRoute(path string, matchType string) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.tbl.Load()
+
+	if pathtree.HasPattern(path) {
+		t.params.Delete(path)
+		r.flush(t, "")
+		t.forgetRoute(path, matchType)
+		return nil
+	}
+
+	if matchType == "exact" {
+		t.exact.Delete(path)
+		t.forgetRoute(path, matchType)
+		return nil
+	}
+
+	t.prefix.Delete(path)
+	r.flush(t, path)
+	t.forgetRoute(path, matchType)
+
+	return nil
+}
+
+// flush removes dynamic cache entries shadowed by prefix ("" flushes every
+// entry) and tallies the result in r.flushes. Called with Router.mu already
+// held for writing.
+func (r *Router) flush(t *tables, prefix string) {
+	if removed := t.dyn.flushShadowedBy(prefix); removed > 0 {
+		r.flushes.Add(uint64(removed))
+	}
+}
+
+// recordRoute appends (or, for a path/matchType that already has a record,
+// overwrites) the replayable description of a user-added route, for
+// Snapshot to serialize later. Called with Router.mu already held for
+// writing.
+func (t *tables) recordRoute(rec routeRecord) {
+	for i, existing := range t.records {
+		if existing.Spec == nil && existing.Path == rec.Path && existing.MatchType == rec.MatchType {
+			t.records[i] = rec
+			return
+		}
+	}
+	t.records = append(t.records, rec)
+}
+
+// forgetRoute drops the record for path/matchType created by recordRoute,
+// mirroring a DeleteRoute call. Called with Router.mu already held for
+// writing.
+func (t *tables) forgetRoute(path, matchType string) {
+	for i, existing := range t.records {
+		if existing.Spec == nil && existing.Path == path && existing.MatchType == matchType {
+			t.records = append(t.records[:i], t.records[i+1:]...)
+			return
+		}
+	}
+}
+
+// RouteLookup is called to return the destination service associated with the best match route for the input path provided
+func (r *Router) RouteLookup(path string) (string, error) {
+	result, err := r.RouteLookupParams(path)
+	return result.Destination, err
+}
+
+// RouteLookupParams is RouteLookup's richer counterpart: it also returns any
+// named parameters bound by a parameterized route
+// (e.g. "/user/bob" against "/user/{name}" binds Params["name"] = "bob").
+// Precedence, most to least specific: exact literal match, parameterized
+// match, prefix match, catch-all match, then "default-service".
+//
+// The exact-match and dynamic-cache fast paths below need no Router.mu:
+// t.exact is a sync.Map and t.dyn has its own internal lock, and a hit on
+// either is the overwhelmingly common case once traffic has warmed the
+// cache up. Only a miss on both falls through to the param/prefix tables,
+// which do need Router.mu since they mutate in place on AddRoute.
+func (r *Router) RouteLookupParams(path string) (LookupResult, error) {
+
+	t := r.tbl.Load()
+
+	// Lookup in the static exact match table, if found, we are done
+
+	if v, ok := t.exact.Load(path); ok {
+		rte := v.(route)
+		fmt.Println("Lookup result -> ", rte.destination)
+		return LookupResult{Destination: rte.destination}, nil
+	}
+
+	// else check the dynamic cache of previously materialized prefix/
+	// default-service hits
+
+	if destination, ok := t.dyn.get(path); ok {
+		r.hits.Add(1)
+		fmt.Println("Lookup result -> ", destination)
+		return LookupResult{Destination: destination}, nil
+	}
+	r.misses.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// else try the parameterized route tree; a catch-all match is kept aside
+	// since it ranks below a plain prefix match, but a literal/param match wins
+	// outright.
+
+	var catchAll LookupResult
+	haveCatchAll := false
+
+	if res, isCatchAll, found := t.params.Lookup(path); found {
+		if !isCatchAll {
+			fmt.Println("Lookup result -> ", res.Destination)
+			return LookupResult{Destination: res.Destination, Params: res.Params}, nil
+		}
+		catchAll = LookupResult{Destination: res.Destination, Params: res.Params}
+		haveCatchAll = true
+	}
+
+	// else searchInPrefixTable (single O(k) walk of the prefix trie)
+
+	if destination, found := t.prefix.Lookup(path); found {
+		fmt.Println("Lookup result -> ", destination)
+		r.createDynamicRoute(t, path, destination)
+		return LookupResult{Destination: destination}, nil
+	}
+
+	if haveCatchAll {
+		fmt.Println("Lookup result -> ", catchAll.Destination)
+		return catchAll, nil
+	}
+
+	// If still not found, the destination defaults to the default service
+	fmt.Println("Lookup result -> default-service")
+	r.createDynamicRoute(t, path, "default-service")
+	return LookupResult{Destination: "default-service"}, nil
+}
+
+// createDynamicRoute materializes path -> destination into t's bounded
+// dynamic cache, tallying an eviction if inserting it pushed the cache over
+// MaxDynamicEntries.
+func (r *Router) createDynamicRoute(t *tables, path string, destination string) {
+	if evicted := t.dyn.set(path, destination); evicted {
+		r.evictions.Add(1)
+	}
+}
+
+func (r *Router) printTables(i int) {
+	t := r.tbl.Load()
+
+	exact := make(map[string]route)
+	t.exact.Range(func(k, v any) bool {
+		exact[k.(string)] = v.(route)
+		return true
+	})
+
+	fmt.Printf("%d) \n", i)
+	fmt.Println("prefixRoutesTable (prefix trie), size =", t.prefix.Len())
+	fmt.Println("exactMatchTable")
+	fmt.Println(exact)
+	fmt.Println("dynamicEntries, size =", t.dyn.len())
+}
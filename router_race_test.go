@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestRouterConcurrentAccess hammers a single Router from many goroutines
+// doing AddRoute, DeleteRoute and RouteLookup simultaneously. It doesn't
+// assert on particular results -- concurrent writers racing for the same
+// path means the final destination is inherently nondeterministic -- it
+// exists to be run with -race and catch data races in the table swap and
+// dynamic-cache bookkeeping.
+func TestRouterConcurrentAccess(t *testing.T) {
+	r := NewRouter()
+
+	const goroutines = 32
+	const opsPerGoroutine = 200
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	for g := 0; g < goroutines; g++ {
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < opsPerGoroutine; i++ {
+				path := fmt.Sprintf("/api/%d", i%10)
+				switch i % 4 {
+				case 0:
+					if err := r.AddRoute(path, "exact", fmt.Sprintf("service-%d-%d", g, i)); err != nil {
+						t.Errorf("AddRoute returned error: %v", err)
+					}
+				case 1:
+					if err := r.AddRoute(path+"/", "prefix", fmt.Sprintf("service-%d-%d", g, i)); err != nil {
+						t.Errorf("AddRoute returned error: %v", err)
+					}
+				case 2:
+					if _, err := r.RouteLookup(path + "/child"); err != nil {
+						t.Errorf("RouteLookup returned error: %v", err)
+					}
+				case 3:
+					if err := r.DeleteRoute(path, "exact"); err != nil {
+						t.Errorf("DeleteRoute returned error: %v", err)
+					}
+				}
+			}
+		}(g)
+	}
+
+	wg.Wait()
+}
+
+// TestRouterConcurrentRouteSpecAccess exercises AddRouteSpec/MatchRequest
+// from many goroutines at once, since t.specs mutates in place and isn't
+// a sync.Map the way t.exact is.
+func TestRouterConcurrentRouteSpecAccess(t *testing.T) {
+	r := NewRouter()
+
+	const goroutines = 16
+	const opsPerGoroutine = 100
+
+	stop := make(chan struct{})
+	var readerWg sync.WaitGroup
+	readerWg.Add(1)
+	go func() {
+		defer readerWg.Done()
+		req := httptest.NewRequest(http.MethodGet, "/svc/5", nil)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				if _, err := r.MatchRequest(req); err != nil {
+					t.Errorf("MatchRequest returned error: %v", err)
+				}
+			}
+		}
+	}()
+
+	var writerWg sync.WaitGroup
+	writerWg.Add(goroutines)
+	for g := 0; g < goroutines; g++ {
+		go func(g int) {
+			defer writerWg.Done()
+			for i := 0; i < opsPerGoroutine; i++ {
+				spec := RouteSpec{Path: fmt.Sprintf("/svc/%d", i%10), MatchType: "exact", Method: "GET"}
+				if err := r.AddRouteSpec(spec, fmt.Sprintf("dest-%d-%d", g, i)); err != nil {
+					t.Errorf("AddRouteSpec returned error: %v", err)
+				}
+			}
+		}(g)
+	}
+	writerWg.Wait()
+
+	close(stop)
+	readerWg.Wait()
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RouteSpec generalizes routing beyond path matching, in the style of
+// skipper's multi-condition routes: a route can additionally require a
+// specific HTTP method, a host matching a regexp, and/or a set of header
+// values. Host and PathRegexp are stored as patterns (rather than compiled
+// *regexp.Regexp) so that a RouteSpec stays a plain, serializable value.
+type RouteSpec struct {
+	Path       string            // used when PathRegexp is empty
+	MatchType  string            // "exact" or "prefix"; only meaningful alongside Path
+	PathRegexp string            // if set, overrides Path/MatchType entirely
+	Method     string            // optional; empty matches any method
+	Host       string            // optional regexp matched against req.Host; empty matches any host
+	Headers    map[string]string // optional; every entry must be present on the request with an equal value
+}
+
+// routeSpecEntry is a compiled, ready-to-match RouteSpec plus its
+// destination. specificity ranks entries for MatchRequest's candidate scan:
+// higher values are tried first.
+type routeSpecEntry struct {
+	spec        RouteSpec
+	destination string
+	hostRe      *regexp.Regexp
+	pathRe      *regexp.Regexp
+	specificity int
+}
+
+// AddRouteSpec registers a multi-condition route on DefaultRouter. Routes
+// are evaluated in decreasing specificity order (exact path match first,
+// then prefix matches longest-first, then path-regexp matches), so a more
+// specific path with a narrower set of conditions is always preferred over
+// a broader one that also matches.
+func AddRouteSpec(spec RouteSpec, destination string) error {
+	return DefaultRouter.AddRouteSpec(spec, destination)
+}
+
+// AddRouteSpec registers a multi-condition route; see the package-level
+// AddRouteSpec for the matching semantics. The entry is filed into whichever
+// of t.specExact/t.specPrefix+t.specPrefixEntries/t.specRegexps matches its
+// path condition, each kept sorted by decreasing specificity, so MatchRequest
+// can look up a candidate set instead of scanning every registered spec.
+func (r *Router) AddRouteSpec(spec RouteSpec, destination string) error {
+
+	entry := &routeSpecEntry{spec: spec, destination: destination}
+
+	if spec.PathRegexp != "" {
+		re, err := regexp.Compile(spec.PathRegexp)
+		if err != nil {
+			return fmt.Errorf("route-matching: invalid PathRegexp %q: %w", spec.PathRegexp, err)
+		}
+		entry.pathRe = re
+	}
+
+	if spec.Host != "" {
+		re, err := regexp.Compile(spec.Host)
+		if err != nil {
+			return fmt.Errorf("route-matching: invalid Host regexp %q: %w", spec.Host, err)
+		}
+		entry.hostRe = re
+	}
+
+	entry.specificity = specEntrySpecificity(spec)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.tbl.Load()
+
+	switch {
+	case entry.pathRe != nil:
+		t.specRegexps = append(t.specRegexps, entry)
+		sortBySpecificityDesc(t.specRegexps)
+	case spec.MatchType == "exact":
+		t.specExact[spec.Path] = append(t.specExact[spec.Path], entry)
+		sortBySpecificityDesc(t.specExact[spec.Path])
+	default:
+		t.specPrefix.Insert(spec.Path, spec.Path)
+		t.specPrefixEntries[spec.Path] = append(t.specPrefixEntries[spec.Path], entry)
+		sortBySpecificityDesc(t.specPrefixEntries[spec.Path])
+	}
+
+	specCopy := spec
+	t.recordRoute(routeRecord{Spec: &specCopy, Destination: destination})
+
+	return nil
+}
+
+// sortBySpecificityDesc sorts entries, all sharing the same path condition,
+// by decreasing specificity so the first one to match a request's
+// Method/Host/Headers is the most specific candidate available.
+func sortBySpecificityDesc(entries []*routeSpecEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].specificity > entries[j].specificity
+	})
+}
+
+// specEntrySpecificity ranks a RouteSpec for candidate ordering: an exact
+// path match beats a prefix match, which beats a path regexp; the length of
+// the path condition breaks ties within each group, and each additional
+// condition (method/host/headers) nudges the route earlier still.
+func specEntrySpecificity(spec RouteSpec) int {
+	base := 0
+	switch {
+	case spec.PathRegexp != "":
+		base = len(spec.PathRegexp)
+	case spec.MatchType == "exact":
+		base = len(spec.Path) + 1_000_000
+	default:
+		base = len(spec.Path) + 500_000
+	}
+
+	if spec.Method != "" {
+		base += 10_000
+	}
+	if spec.Host != "" {
+		base += 10_000
+	}
+	base += len(spec.Headers) * 10_000
+
+	return base
+}
+
+func (e *routeSpecEntry) matchesConditions(req *http.Request) bool {
+	if e.spec.Method != "" && !strings.EqualFold(e.spec.Method, req.Method) {
+		return false
+	}
+	if e.hostRe != nil && !e.hostRe.MatchString(req.Host) {
+		return false
+	}
+	for name, want := range e.spec.Headers {
+		if got := req.Header.Get(name); got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchRequest matches req against DefaultRouter's registered RouteSpecs.
+func MatchRequest(req *http.Request) (LookupResult, error) {
+	return DefaultRouter.MatchRequest(req)
+}
+
+// MatchRequest matches an *http.Request against the registered RouteSpecs
+// first, and falls back to the plain path-only routing (RouteLookupParams)
+// if none of them fully match -- so RouteSpec-based routes layer on top of
+// the existing exact/prefix/param routes rather than replacing them.
+//
+// Path matching uses the same fast tables as plain routes (t.specExact is a
+// map keyed like t.exact; t.specPrefix is a prefixtrie.Table like t.prefix)
+// to build a candidate set instead of scanning every registered RouteSpec:
+// an exact match on path, then every registered prefix of path from longest
+// to shortest. specEntrySpecificity guarantees any exact-path candidate
+// outranks any prefix candidate, which in turn outranks any PathRegexp
+// candidate, so trying the groups in that order and, within a group, the
+// specificity-sorted entries in order, reproduces the same decreasing-
+// specificity evaluation the single combined list used to do -- each group
+// is just reached by a lookup instead of a linear scan.
+func (r *Router) MatchRequest(req *http.Request) (LookupResult, error) {
+	path := req.URL.Path
+
+	t := r.tbl.Load()
+
+	r.mu.RLock()
+
+	if e, ok := firstMatch(t.specExact[path], req); ok {
+		r.mu.RUnlock()
+		return LookupResult{Destination: e.destination}, nil
+	}
+
+	for _, prefix := range t.specPrefix.LookupChain(path) {
+		if e, ok := firstMatch(t.specPrefixEntries[prefix], req); ok {
+			r.mu.RUnlock()
+			return LookupResult{Destination: e.destination}, nil
+		}
+	}
+
+	for _, e := range t.specRegexps {
+		if e.pathRe.MatchString(path) && e.matchesConditions(req) {
+			r.mu.RUnlock()
+			return LookupResult{Destination: e.destination}, nil
+		}
+	}
+
+	r.mu.RUnlock()
+
+	return r.RouteLookupParams(path)
+}
+
+// firstMatch returns the first entry (entries are pre-sorted by decreasing
+// specificity) whose Method/Host/Headers conditions are all satisfied.
+func firstMatch(entries []*routeSpecEntry, req *http.Request) (*routeSpecEntry, bool) {
+	for _, e := range entries {
+		if e.matchesConditions(req) {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// Handler adapts a Router to net/http so it can be dropped into a standard
+// Go HTTP server. It matches the incoming request against that Router and
+// reports the resolved destination service; callers that want to actually
+// proxy the request can wrap Handler or call Router.MatchRequest directly.
+type Handler struct {
+	router *Router
+}
+
+// NewHandler returns an http.Handler backed by r.MatchRequest.
+func NewHandler(r *Router) Handler {
+	return Handler{router: r}
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	result, err := h.router.MatchRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Route-Destination", result.Destination)
+	fmt.Fprintln(w, result.Destination)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatchRequestMethodAndHost(t *testing.T) {
+
+	r := NewRouter()
+
+	if err := r.AddRouteSpec(RouteSpec{Path: "/orders", MatchType: "exact", Method: "GET"}, "orders-read"); err != nil {
+		t.Fatalf("AddRouteSpec GET /orders returned error: %v", err)
+	}
+	if err := r.AddRouteSpec(RouteSpec{Path: "/orders", MatchType: "exact", Method: "POST"}, "orders-write"); err != nil {
+		t.Fatalf("AddRouteSpec POST /orders returned error: %v", err)
+	}
+	if err := r.AddRouteSpec(RouteSpec{Path: "/admin", MatchType: "prefix", Host: `^admin\.internal$`}, "admin-console"); err != nil {
+		t.Fatalf("AddRouteSpec /admin with Host returned error: %v", err)
+	}
+
+	get := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	if result, err := r.MatchRequest(get); err != nil || result.Destination != "orders-read" {
+		t.Errorf("MatchRequest(GET /orders) = (%+v, %v), want orders-read", result, err)
+	}
+
+	post := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	if result, err := r.MatchRequest(post); err != nil || result.Destination != "orders-write" {
+		t.Errorf("MatchRequest(POST /orders) = (%+v, %v), want orders-write", result, err)
+	}
+
+	adminReq := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+	adminReq.Host = "admin.internal"
+	if result, err := r.MatchRequest(adminReq); err != nil || result.Destination != "admin-console" {
+		t.Errorf("MatchRequest(admin host) = (%+v, %v), want admin-console", result, err)
+	}
+
+	wrongHost := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+	wrongHost.Host = "public.example.com"
+	if result, err := r.MatchRequest(wrongHost); err != nil || result.Destination != "default-service" {
+		t.Errorf("MatchRequest(non-admin host) = (%+v, %v), want default-service fallback", result, err)
+	}
+}
+
+func TestHandlerServeHTTP(t *testing.T) {
+	r := NewRouter()
+
+	if err := r.AddRouteSpec(RouteSpec{Path: "/health", MatchType: "exact"}, "health-check"); err != nil {
+		t.Fatalf("AddRouteSpec returned error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	NewHandler(r).ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Route-Destination"); got != "health-check" {
+		t.Errorf("X-Route-Destination header = %q, want health-check", got)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// routeRecord is a serializable, replayable description of a single
+// user-added route. Snapshot walks a Router's records rather than its
+// tables directly, since the prefix trie and param tree don't expose their
+// contents for enumeration; Restore replays each record through the same
+// AddRoute/AddRouteSpec logic used at runtime, so the rebuilt tables are
+// indistinguishable from ones built by calling those directly.
+type routeRecord struct {
+	Path        string     `json:"path,omitempty"`
+	MatchType   string     `json:"matchType,omitempty"`
+	Destination string     `json:"destination"`
+	Spec        *RouteSpec `json:"spec,omitempty"`
+}
+
+// Snapshot serializes every user-added route on DefaultRouter (excluding
+// dynamically materialized cache entries) to JSON.
+func Snapshot() ([]byte, error) {
+	return DefaultRouter.Snapshot()
+}
+
+// Snapshot serializes every user-added route (excluding dynamically
+// materialized cache entries) to JSON, in a form Restore can read back.
+func (r *Router) Snapshot() ([]byte, error) {
+	t := r.tbl.Load()
+	data, err := json.MarshalIndent(t.records, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("route-matching: encoding snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// Restore rebuilds DefaultRouter's tables from a snapshot produced by
+// Snapshot.
+func Restore(src io.Reader) error {
+	return DefaultRouter.Restore(src)
+}
+
+// Restore reads a snapshot produced by Snapshot and atomically swaps it in
+// as r's tables, replacing whatever routes were previously registered.
+// Readers never observe a partially rebuilt table: the new tables are
+// built from scratch and only published via r.tbl.Store once complete.
+func (r *Router) Restore(src io.Reader) error {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return fmt.Errorf("route-matching: reading snapshot: %w", err)
+	}
+
+	var records []routeRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("route-matching: decoding snapshot: %w", err)
+	}
+
+	fresh := &Router{MaxDynamicEntries: r.MaxDynamicEntries}
+	fresh.tbl.Store(newTables(fresh.maxDynamicEntries()))
+	for _, rec := range records {
+		if rec.Spec != nil {
+			if err := fresh.AddRouteSpec(*rec.Spec, rec.Destination); err != nil {
+				return fmt.Errorf("route-matching: replaying spec route: %w", err)
+			}
+			continue
+		}
+		if err := fresh.AddRoute(rec.Path, rec.MatchType, rec.Destination); err != nil {
+			return fmt.Errorf("route-matching: replaying route %q: %w", rec.Path, err)
+		}
+	}
+
+	r.mu.Lock()
+	r.tbl.Store(fresh.tbl.Load())
+	r.mu.Unlock()
+	return nil
+}
+
+// WatchFile polls path on DefaultRouter every interval; see Router.WatchFile.
+func WatchFile(path string, interval time.Duration) (stop func()) {
+	return DefaultRouter.WatchFile(path, interval)
+}
+
+// WatchFile polls path every interval and, whenever its contents change,
+// reloads it into r via Restore -- so the routes served by a running
+// process can be updated without a restart. The returned stop function
+// halts the polling goroutine; it is safe to call more than once.
+func (r *Router) WatchFile(path string, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var lastHash [sha256.Size]byte
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				data, err := os.ReadFile(path)
+				if err != nil {
+					continue
+				}
+				hash := sha256.Sum256(data)
+				if hash == lastHash {
+					continue
+				}
+				if err := r.Restore(bytes.NewReader(data)); err != nil {
+					continue
+				}
+				lastHash = hash
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
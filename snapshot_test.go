@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	r := NewRouter()
+
+	if err := r.AddRoute("/api/1", "exact", "service-1"); err != nil {
+		t.Fatalf("AddRoute returned error: %v", err)
+	}
+	if err := r.AddRoute("/api/2/", "prefix", "service-2"); err != nil {
+		t.Fatalf("AddRoute returned error: %v", err)
+	}
+	if err := r.AddRoute("/user/{name}", "exact", "by-name"); err != nil {
+		t.Fatalf("AddRoute returned error: %v", err)
+	}
+	if err := r.AddRouteSpec(RouteSpec{Path: "/orders", MatchType: "exact", Method: "GET"}, "orders-read"); err != nil {
+		t.Fatalf("AddRouteSpec returned error: %v", err)
+	}
+
+	data, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %v", err)
+	}
+
+	restored := NewRouter()
+	if err := restored.Restore(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+
+	if got, err := restored.RouteLookup("/api/1"); err != nil || got != "service-1" {
+		t.Errorf("RouteLookup(/api/1) = (%q, %v), want service-1", got, err)
+	}
+	if got, err := restored.RouteLookup("/api/2/x"); err != nil || got != "service-2" {
+		t.Errorf("RouteLookup(/api/2/x) = (%q, %v), want service-2", got, err)
+	}
+	result, err := restored.RouteLookupParams("/user/bob")
+	if err != nil || result.Destination != "by-name" || result.Params["name"] != "bob" {
+		t.Errorf("RouteLookupParams(/user/bob) = (%+v, %v), want by-name with name=bob", result, err)
+	}
+
+	// A route deleted before the snapshot was taken must not reappear on restore.
+	if err := r.DeleteRoute("/api/1", "exact"); err != nil {
+		t.Fatalf("DeleteRoute returned error: %v", err)
+	}
+	data, err = r.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %v", err)
+	}
+	restored = NewRouter()
+	if err := restored.Restore(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if got, err := restored.RouteLookup("/api/1"); err != nil || got != "default-service" {
+		t.Errorf("RouteLookup(/api/1) after delete+restore = (%q, %v), want default-service", got, err)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "routes.json")
+
+	seed := NewRouter()
+	if err := seed.AddRoute("/api/1", "exact", "service-1"); err != nil {
+		t.Fatalf("AddRoute returned error: %v", err)
+	}
+	data, err := seed.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+
+	r := NewRouter()
+	stop := r.WatchFile(path, 10*time.Millisecond)
+	defer stop()
+
+	waitFor(t, func() bool {
+		got, err := r.RouteLookup("/api/1")
+		return err == nil && got == "service-1"
+	})
+
+	seed2 := NewRouter()
+	if err := seed2.AddRoute("/api/1", "exact", "service-2"); err != nil {
+		t.Fatalf("AddRoute returned error: %v", err)
+	}
+	data, err = seed2.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		got, err := r.RouteLookup("/api/1")
+		return err == nil && got == "service-2"
+	})
+}
+
+// waitFor polls cond until it reports true or a short deadline passes,
+// failing the test if the deadline is hit first.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("condition not met before deadline")
+}